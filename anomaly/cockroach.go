@@ -0,0 +1,19 @@
+package anomaly
+
+import "github.com/jackc/pgx/v4/pgxpool"
+
+// NewCockroachDB returns a DB backed by pool, talking to a CockroachDB
+// cluster over its Postgres-compatible wire protocol. CockroachDB only
+// implements SERIALIZABLE (every other level is silently upgraded to it),
+// so SupportedIsoLevels reports just that - AnomalyMatrix skips the rest
+// rather than asserting anomalies that can never happen here.
+//
+// CockroachDB reports the same conflict as Postgres's 40001 (its
+// TransactionRetryError), so it reuses postgresDB/postgresTx as-is.
+func NewCockroachDB(pool *pgxpool.Pool) DB {
+	return &postgresDB{
+		name: "cockroachdb",
+		pool: pool,
+		iso:  []IsoLevel{Serializable},
+	}
+}
@@ -0,0 +1,53 @@
+// Package anomaly turns the isolation-anomaly demos in the parent package
+// into a backend-agnostic comparison suite: the same AnomalyScenario can be
+// run against Postgres, MySQL, or CockroachDB by swapping the DB
+// implementation it's given.
+package anomaly
+
+import "context"
+
+// IsoLevel is a standard SQL isolation level. Not every backend supports
+// every level - see DB.SupportedIsoLevels.
+type IsoLevel string
+
+const (
+	ReadUncommitted IsoLevel = "READ UNCOMMITTED"
+	ReadCommitted   IsoLevel = "READ COMMITTED"
+	RepeatableRead  IsoLevel = "REPEATABLE READ"
+	Serializable    IsoLevel = "SERIALIZABLE"
+)
+
+// Tx is one open transaction. It exposes just enough of the `balances`
+// table for the scenarios in this package to read and write it, so
+// scenarios don't need to know whether they're driving pgx or database/sql
+// underneath.
+type Tx interface {
+	SetBalance(ctx context.Context, name string, value int) error
+	GetBalance(ctx context.Context, name string) (int, error)
+	CountNegativeBalances(ctx context.Context) (int, error)
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// DB is a backend that can set up the `balances` table and open
+// transactions against it at a given isolation level.
+type DB interface {
+	// Name identifies the backend in AnomalyMatrix output, e.g. "postgres".
+	Name() string
+
+	// SupportedIsoLevels lists the isolation levels Run accepts, in
+	// ascending strictness. CockroachDB, for example, only offers
+	// Serializable.
+	SupportedIsoLevels() []IsoLevel
+
+	// Setup (re)creates the `balances` table.
+	Setup(ctx context.Context) error
+
+	// Reset truncates `balances` and reinserts the Alice/Bob starting rows
+	// that every scenario assumes.
+	Reset(ctx context.Context) error
+
+	BeginTx(ctx context.Context, iso IsoLevel) (Tx, error)
+
+	Close()
+}
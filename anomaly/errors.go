@@ -0,0 +1,10 @@
+package anomaly
+
+import "errors"
+
+// ErrSerializationFailure is returned by Tx.Commit (or whichever call
+// provoked it) when a backend rejects a transaction because it could not
+// be placed in any serial ordering with its concurrent peers - Postgres's
+// 40001/40P01, MySQL's deadlock/lock-wait-timeout errors, and
+// CockroachDB's TransactionRetryError all map to this.
+var ErrSerializationFailure = errors.New("anomaly: serialization failure")
@@ -0,0 +1,84 @@
+package anomaly
+
+import (
+	"context"
+	"errors"
+)
+
+// MatrixResult is one (backend, isolation level, scenario) cell of an
+// AnomalyMatrix run.
+type MatrixResult struct {
+	Backend   string
+	IsoLevel  IsoLevel
+	Scenario  string
+	Prevented bool
+	Observed  bool
+	Err       error
+}
+
+// AnomalyMatrix runs every scenario against every isolation level every
+// backend supports, producing a table of which anomalies each
+// backend/level combination prevents.
+type AnomalyMatrix struct {
+	Backends  []DB
+	Scenarios []AnomalyScenario
+}
+
+// Run executes the full matrix and returns one MatrixResult per cell, in
+// backend -> isolation level -> scenario order.
+func (m AnomalyMatrix) Run(ctx context.Context) ([]MatrixResult, error) {
+	var results []MatrixResult
+
+	for _, db := range m.Backends {
+		for _, iso := range db.SupportedIsoLevels() {
+			for _, scenario := range m.Scenarios {
+				result, err := m.runCell(ctx, db, iso, scenario)
+				if err != nil {
+					return results, err
+				}
+				results = append(results, result)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+func (m AnomalyMatrix) runCell(ctx context.Context, db DB, iso IsoLevel, scenario AnomalyScenario) (MatrixResult, error) {
+	if err := db.Reset(ctx); err != nil {
+		return MatrixResult{}, err
+	}
+	if err := scenario.Setup(ctx, db); err != nil {
+		return MatrixResult{}, err
+	}
+
+	tx1, err := db.BeginTx(ctx, iso)
+	if err != nil {
+		return MatrixResult{}, err
+	}
+	tx2, err := db.BeginTx(ctx, iso)
+	if err != nil {
+		_ = tx1.Rollback(ctx)
+		return MatrixResult{}, err
+	}
+
+	observed, err := scenario.Run(ctx, tx1, tx2)
+
+	result := MatrixResult{
+		Backend:  db.Name(),
+		IsoLevel: iso,
+		Scenario: scenario.Name(),
+		Observed: observed,
+	}
+
+	switch {
+	case err == nil:
+		result.Prevented = false
+	case errors.Is(err, ErrSerializationFailure):
+		result.Prevented = true
+	default:
+		result.Err = err
+	}
+
+	return result, nil
+}
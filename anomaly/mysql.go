@@ -0,0 +1,128 @@
+package anomaly
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlDeadlockErrno and mysqlLockWaitTimeoutErrno are the MySQL error
+// numbers that stand in for Postgres's 40001/40P01: a deadlock the server
+// broke by killing one of the transactions, and a row lock wait that timed
+// out because REPEATABLE READ (MySQL's default) doesn't detect the
+// write-write conflicts Postgres would.
+const (
+	mysqlDeadlockErrno        = 1213
+	mysqlLockWaitTimeoutErrno = 1205
+)
+
+type mysqlDB struct {
+	db *sql.DB
+}
+
+// NewMySQL returns a DB backed by db, supporting all four standard SQL
+// isolation levels.
+func NewMySQL(db *sql.DB) DB {
+	return &mysqlDB{db: db}
+}
+
+func (m *mysqlDB) Name() string { return "mysql" }
+
+func (m *mysqlDB) SupportedIsoLevels() []IsoLevel {
+	return []IsoLevel{ReadUncommitted, ReadCommitted, RepeatableRead, Serializable}
+}
+
+func (m *mysqlDB) Close() { m.db.Close() }
+
+func (m *mysqlDB) Setup(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS balances (
+	name VARCHAR(64) NOT NULL PRIMARY KEY,
+	value INT NOT NULL
+);
+	`)
+	return err
+}
+
+func (m *mysqlDB) Reset(ctx context.Context) error {
+	if _, err := m.db.ExecContext(ctx, "TRUNCATE TABLE balances"); err != nil {
+		return err
+	}
+	_, err := m.db.ExecContext(ctx, `
+INSERT INTO balances (name, value) VALUES ('Alice', 100), ('Bob', 100);
+	`)
+	return err
+}
+
+func (m *mysqlDB) BeginTx(ctx context.Context, iso IsoLevel) (Tx, error) {
+	level, err := sqlIsoLevel(iso)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{Isolation: level})
+	if err != nil {
+		return nil, err
+	}
+	return &mysqlTx{tx: tx}, nil
+}
+
+func sqlIsoLevel(iso IsoLevel) (sql.IsolationLevel, error) {
+	switch iso {
+	case ReadUncommitted:
+		return sql.LevelReadUncommitted, nil
+	case ReadCommitted:
+		return sql.LevelReadCommitted, nil
+	case RepeatableRead:
+		return sql.LevelRepeatableRead, nil
+	case Serializable:
+		return sql.LevelSerializable, nil
+	default:
+		return 0, fmt.Errorf("anomaly: unsupported isolation level %q for mysql", iso)
+	}
+}
+
+type mysqlTx struct {
+	tx *sql.Tx
+}
+
+func (t *mysqlTx) SetBalance(ctx context.Context, name string, value int) error {
+	_, err := t.tx.ExecContext(ctx, "UPDATE balances SET value = ? WHERE name = ?", value, name)
+	return wrapIfMySQLSerializationFailure(err)
+}
+
+func (t *mysqlTx) GetBalance(ctx context.Context, name string) (int, error) {
+	var value int
+	err := t.tx.QueryRowContext(ctx, "SELECT value FROM balances WHERE name = ?", name).Scan(&value)
+	return value, wrapIfMySQLSerializationFailure(err)
+}
+
+func (t *mysqlTx) CountNegativeBalances(ctx context.Context) (int, error) {
+	var count int
+	err := t.tx.QueryRowContext(ctx, "SELECT count(*) FROM balances WHERE value < 0").Scan(&count)
+	return count, wrapIfMySQLSerializationFailure(err)
+}
+
+func (t *mysqlTx) Commit(ctx context.Context) error {
+	return wrapIfMySQLSerializationFailure(t.tx.Commit())
+}
+
+func (t *mysqlTx) Rollback(ctx context.Context) error {
+	return t.tx.Rollback()
+}
+
+// wrapIfMySQLSerializationFailure wraps err with ErrSerializationFailure
+// when MySQL reports a deadlock or a lock wait timeout, the two ways a
+// conflicting transaction surfaces here instead of Postgres's 40001/40P01.
+func wrapIfMySQLSerializationFailure(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return err
+	}
+	if mysqlErr.Number == mysqlDeadlockErrno || mysqlErr.Number == mysqlLockWaitTimeoutErrno {
+		return fmt.Errorf("%w: %v", ErrSerializationFailure, err)
+	}
+	return err
+}
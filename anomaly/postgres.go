@@ -0,0 +1,107 @@
+package anomaly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// postgresDB is the Postgres DB backend. It's also the backend CockroachDB
+// reuses, since both speak the pgx wire protocol.
+type postgresDB struct {
+	name string
+	pool *pgxpool.Pool
+	iso  []IsoLevel
+}
+
+// NewPostgres returns a DB backed by pool, supporting every standard
+// isolation level (Postgres silently upgrades ReadUncommitted to
+// ReadCommitted, same as BEGIN does).
+func NewPostgres(pool *pgxpool.Pool) DB {
+	return &postgresDB{
+		name: "postgres",
+		pool: pool,
+		iso:  []IsoLevel{ReadUncommitted, ReadCommitted, RepeatableRead, Serializable},
+	}
+}
+
+func (db *postgresDB) Name() string                  { return db.name }
+func (db *postgresDB) SupportedIsoLevels() []IsoLevel { return db.iso }
+func (db *postgresDB) Close()                         { db.pool.Close() }
+
+func (db *postgresDB) Setup(ctx context.Context) error {
+	_, err := db.pool.Exec(ctx, `
+CREATE SCHEMA IF NOT EXISTS anomaly;
+DROP TABLE IF EXISTS anomaly.balances;
+CREATE TABLE anomaly.balances (
+	name TEXT NOT NULL PRIMARY KEY,
+	value int NOT NULL
+);
+	`)
+	return err
+}
+
+func (db *postgresDB) Reset(ctx context.Context) error {
+	_, err := db.pool.Exec(ctx, `
+TRUNCATE anomaly.balances RESTART IDENTITY CASCADE;
+INSERT INTO anomaly.balances VALUES ('Alice', 100);
+INSERT INTO anomaly.balances VALUES ('Bob', 100);
+	`)
+	return err
+}
+
+func (db *postgresDB) BeginTx(ctx context.Context, iso IsoLevel) (Tx, error) {
+	tx, err := db.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.TxIsoLevel(iso)})
+	if err != nil {
+		return nil, err
+	}
+	return &postgresTx{tx: tx}, nil
+}
+
+type postgresTx struct {
+	tx pgx.Tx
+}
+
+func (t *postgresTx) SetBalance(ctx context.Context, name string, value int) error {
+	_, err := t.tx.Exec(ctx, "UPDATE anomaly.balances SET value = $1 WHERE name = $2", value, name)
+	return wrapIfSerializationFailure(err)
+}
+
+func (t *postgresTx) GetBalance(ctx context.Context, name string) (int, error) {
+	var value int
+	err := t.tx.QueryRow(ctx, "SELECT value FROM anomaly.balances WHERE name = $1", name).Scan(&value)
+	return value, wrapIfSerializationFailure(err)
+}
+
+func (t *postgresTx) CountNegativeBalances(ctx context.Context) (int, error) {
+	var count int
+	err := t.tx.QueryRow(ctx, "SELECT count(*) FROM anomaly.balances WHERE value < 0").Scan(&count)
+	return count, wrapIfSerializationFailure(err)
+}
+
+func (t *postgresTx) Commit(ctx context.Context) error {
+	return wrapIfSerializationFailure(t.tx.Commit(ctx))
+}
+
+func (t *postgresTx) Rollback(ctx context.Context) error {
+	return t.tx.Rollback(ctx)
+}
+
+// wrapIfSerializationFailure wraps err with ErrSerializationFailure when it
+// is a Postgres serialization failure (40001) or deadlock (40P01), so
+// scenarios can check errors.Is(err, ErrSerializationFailure) without
+// caring which backend produced it.
+func wrapIfSerializationFailure(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	if pgErr.Code == "40001" || pgErr.Code == "40P01" {
+		return fmt.Errorf("%w: %v", ErrSerializationFailure, err)
+	}
+	return err
+}
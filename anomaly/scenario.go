@@ -0,0 +1,207 @@
+// The five scenarios below are the backend-agnostic counterparts of
+// DirtyRead, NonRepeatableRead, PhantomRead, LostUpdate, and WriteSkew in
+// the parent package. They can't simply call those functions: the parent
+// package's ConnOrTx is pinned to pgx's Exec/Query signatures, which a
+// database/sql-backed MySQL Tx can't implement, so the same read-then-write
+// sequence is expressed again here against the Tx interface instead. Treat
+// a behavior change to one of the parent package's anomaly functions as a
+// signal to check whether its scenario here needs the same change.
+package anomaly
+
+import "context"
+
+// AnomalyScenario is a single isolation anomaly, expressed against the Tx
+// abstraction so it can run unmodified against any DB backend.
+type AnomalyScenario interface {
+	// Name identifies the scenario in AnomalyMatrix output, e.g. "WriteSkew".
+	Name() string
+
+	// Setup prepares any state the scenario needs beyond the Alice/Bob rows
+	// db.Reset already provides. Most scenarios need nothing extra.
+	Setup(ctx context.Context, db DB) error
+
+	// Run drives tx1 and tx2 through the anomaly and reports whether it
+	// was observed (true) or not (false). A backend that prevents the
+	// anomaly by rejecting a transaction surfaces that as an error wrapping
+	// ErrSerializationFailure; any other error should be treated as a
+	// scenario failure, not as "anomaly prevented".
+	Run(ctx context.Context, tx1, tx2 Tx) (bool, error)
+}
+
+type dirtyReadScenario struct{}
+
+// DirtyReadScenario is the DirtyRead anomaly: tx2 reads a value tx1 wrote
+// but hasn't committed yet. No standard isolation level permits this.
+func DirtyReadScenario() AnomalyScenario { return dirtyReadScenario{} }
+
+func (dirtyReadScenario) Name() string                             { return "DirtyRead" }
+func (dirtyReadScenario) Setup(ctx context.Context, db DB) error    { return nil }
+func (dirtyReadScenario) Run(ctx context.Context, tx1, tx2 Tx) (bool, error) {
+	defer tx1.Rollback(ctx)
+	defer tx2.Rollback(ctx)
+
+	if err := tx1.SetBalance(ctx, "Alice", 150); err != nil {
+		return false, err
+	}
+
+	aliceBal, err := tx2.GetBalance(ctx, "Alice")
+	if err != nil {
+		return false, err
+	}
+
+	return aliceBal == 150, nil
+}
+
+type nonRepeatableReadScenario struct{}
+
+// NonRepeatableReadScenario is the NonRepeatableRead (read skew) anomaly:
+// tx1 reads the same row twice and sees two different committed values.
+func NonRepeatableReadScenario() AnomalyScenario { return nonRepeatableReadScenario{} }
+
+func (nonRepeatableReadScenario) Name() string                          { return "NonRepeatableRead" }
+func (nonRepeatableReadScenario) Setup(ctx context.Context, db DB) error { return nil }
+func (nonRepeatableReadScenario) Run(ctx context.Context, tx1, tx2 Tx) (bool, error) {
+	defer tx1.Rollback(ctx)
+	defer tx2.Rollback(ctx)
+
+	first, err := tx1.GetBalance(ctx, "Alice")
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx2.SetBalance(ctx, "Alice", 150); err != nil {
+		return false, err
+	}
+	if err := tx2.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	second, err := tx1.GetBalance(ctx, "Alice")
+	if err != nil {
+		return false, err
+	}
+	if err := tx1.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return first != second, nil
+}
+
+type phantomReadScenario struct{}
+
+// PhantomReadScenario is NonRepeatableRead's range-query counterpart: tx1
+// re-runs the same range query and sees a row that didn't exist the first
+// time.
+func PhantomReadScenario() AnomalyScenario { return phantomReadScenario{} }
+
+func (phantomReadScenario) Name() string                          { return "PhantomRead" }
+func (phantomReadScenario) Setup(ctx context.Context, db DB) error { return nil }
+func (phantomReadScenario) Run(ctx context.Context, tx1, tx2 Tx) (bool, error) {
+	defer tx1.Rollback(ctx)
+	defer tx2.Rollback(ctx)
+
+	before, err := tx1.CountNegativeBalances(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx2.SetBalance(ctx, "Alice", -100); err != nil {
+		return false, err
+	}
+	if err := tx2.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	after, err := tx1.CountNegativeBalances(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err := tx1.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return before == 0 && after == 1, nil
+}
+
+type lostUpdateScenario struct{}
+
+// LostUpdateScenario: tx1 and tx2 both read Alice's balance, then each
+// writes it back based on what they read; whichever commits second
+// silently discards the first one's write unless the backend catches it.
+func LostUpdateScenario() AnomalyScenario { return lostUpdateScenario{} }
+
+func (lostUpdateScenario) Name() string                          { return "LostUpdate" }
+func (lostUpdateScenario) Setup(ctx context.Context, db DB) error { return nil }
+func (lostUpdateScenario) Run(ctx context.Context, tx1, tx2 Tx) (bool, error) {
+	defer tx1.Rollback(ctx)
+	defer tx2.Rollback(ctx)
+
+	aliceBal1, err := tx1.GetBalance(ctx, "Alice")
+	if err != nil {
+		return false, err
+	}
+	aliceBal2, err := tx2.GetBalance(ctx, "Alice")
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx1.SetBalance(ctx, "Alice", aliceBal1+50); err != nil {
+		return false, err
+	}
+	if err := tx1.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	if err := tx2.SetBalance(ctx, "Alice", aliceBal2+100); err != nil {
+		// Expected at RepeatableRead and above: callers distinguish
+		// "prevented" from "scenario itself failed" via
+		// errors.Is(err, ErrSerializationFailure).
+		return false, err
+	}
+	if err := tx2.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+type writeSkewScenario struct{}
+
+// WriteSkewScenario: tx1 reads Alice and writes Bob, tx2 reads Bob and
+// writes Alice. Neither write conflicts with the other directly, but
+// together they can violate an invariant (e.g. "at least one balance stays
+// non-negative") that no serial ordering of the two transactions would.
+func WriteSkewScenario() AnomalyScenario { return writeSkewScenario{} }
+
+func (writeSkewScenario) Name() string                          { return "WriteSkew" }
+func (writeSkewScenario) Setup(ctx context.Context, db DB) error { return nil }
+func (writeSkewScenario) Run(ctx context.Context, tx1, tx2 Tx) (bool, error) {
+	defer tx1.Rollback(ctx)
+	defer tx2.Rollback(ctx)
+
+	aliceBal, err := tx1.GetBalance(ctx, "Alice")
+	if err != nil {
+		return false, err
+	}
+	bobBal, err := tx2.GetBalance(ctx, "Bob")
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx1.SetBalance(ctx, "Bob", aliceBal+50); err != nil {
+		return false, err
+	}
+	if err := tx1.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	if err := tx2.SetBalance(ctx, "Alice", bobBal+50); err != nil {
+		// Only expected at Serializable.
+		return false, err
+	}
+	if err := tx2.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
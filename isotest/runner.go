@@ -0,0 +1,281 @@
+package isotest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// blockPollInterval is how often the runner checks pg_stat_activity to see
+// whether a dispatched step is waiting on a lock.
+const blockPollInterval = 20 * time.Millisecond
+
+// StepOutcome is the observed result of dispatching one step.
+type StepOutcome struct {
+	Step     string
+	Session  string
+	Observed string
+	Err      error
+
+	// Matched is only set when the step declared an Expected value; it
+	// reports whether Observed (or the error) matched it.
+	Matched *bool
+}
+
+// PermutationResult is the outcome of running every step in a single
+// Permutation.
+type PermutationResult struct {
+	Permutation Permutation
+	Steps       []StepOutcome
+}
+
+// Runner executes Specs against a pool, opening one connection per session
+// so that each session gets its own Postgres backend (and therefore its own
+// locks, snapshot, and pg_backend_pid()).
+type Runner struct {
+	pool *pgxpool.Pool
+}
+
+// NewRunner returns a Runner that acquires session connections from pool.
+func NewRunner(pool *pgxpool.Pool) *Runner {
+	return &Runner{pool: pool}
+}
+
+// sessionConn is one session's dedicated connection.
+type sessionConn struct {
+	name string
+	conn *pgxpool.Conn
+	pid  int32
+}
+
+// Run executes every permutation in spec and returns their results in
+// order. Each permutation gets a fresh run of spec.Setup (and, if present,
+// spec.Teardown afterwards) on its own connection.
+func (r *Runner) Run(ctx context.Context, spec Spec) ([]PermutationResult, error) {
+	results := make([]PermutationResult, 0, len(spec.Permutations))
+
+	for _, perm := range spec.Permutations {
+		result, err := r.runPermutation(ctx, spec, perm)
+		if err != nil {
+			return results, fmt.Errorf("isotest: permutation %v: %w", perm, err)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (r *Runner) runPermutation(ctx context.Context, spec Spec, perm Permutation) (PermutationResult, error) {
+	if spec.Setup != "" {
+		setupConn, err := r.pool.Acquire(ctx)
+		if err != nil {
+			return PermutationResult{}, fmt.Errorf("acquire setup conn: %w", err)
+		}
+		_, err = setupConn.Exec(ctx, spec.Setup)
+		setupConn.Release()
+		if err != nil {
+			return PermutationResult{}, fmt.Errorf("setup: %w", err)
+		}
+	}
+
+	sessions, err := r.openSessions(ctx, spec)
+	if err != nil {
+		return PermutationResult{}, err
+	}
+	defer func() {
+		for _, sc := range sessions {
+			sc.conn.Release()
+		}
+	}()
+
+	outcomes := r.dispatchPermutation(ctx, spec, sessions, perm)
+
+	if spec.Teardown != "" {
+		teardownConn, err := r.pool.Acquire(ctx)
+		if err == nil {
+			_, _ = teardownConn.Exec(ctx, spec.Teardown)
+			teardownConn.Release()
+		}
+	}
+
+	return PermutationResult{Permutation: perm, Steps: outcomes}, nil
+}
+
+// openSessions acquires one connection per session declared in spec and
+// records its backend pid, which isBlocked uses to watch pg_stat_activity.
+func (r *Runner) openSessions(ctx context.Context, spec Spec) (map[string]*sessionConn, error) {
+	sessions := make(map[string]*sessionConn, len(spec.Sessions))
+	for _, sess := range spec.Sessions {
+		conn, err := r.pool.Acquire(ctx)
+		if err != nil {
+			releaseSessions(sessions)
+			return nil, fmt.Errorf("acquire conn for session %q: %w", sess.Name, err)
+		}
+
+		var pid int32
+		if err := conn.QueryRow(ctx, "SELECT pg_backend_pid()").Scan(&pid); err != nil {
+			conn.Release()
+			releaseSessions(sessions)
+			return nil, fmt.Errorf("pg_backend_pid for session %q: %w", sess.Name, err)
+		}
+
+		sessions[sess.Name] = &sessionConn{name: sess.Name, conn: conn, pid: pid}
+	}
+	return sessions, nil
+}
+
+// releaseSessions releases every connection already acquired into sessions.
+// It's used to unwind openSessions when a later session in the loop fails,
+// so a partial failure doesn't leak the sessions acquired before it.
+func releaseSessions(sessions map[string]*sessionConn) {
+	for _, sc := range sessions {
+		sc.conn.Release()
+	}
+}
+
+// inFlight is a step that has been dispatched but hasn't finished (it may
+// be blocked on a lock, or simply still running).
+type inFlight struct {
+	stepName string
+	session  string
+	done     chan StepOutcome
+}
+
+// dispatchPermutation walks perm in order, dispatching each step on its
+// session's connection. When a step blocks on a lock it is set aside and
+// the runner moves on to the rest of the permutation, coming back to
+// collect the blocked step's result once it finally completes (typically
+// once whatever blocked it commits or rolls back).
+func (r *Runner) dispatchPermutation(ctx context.Context, spec Spec, sessions map[string]*sessionConn, perm Permutation) []StepOutcome {
+	outcomeByStep := make(map[string]StepOutcome, len(perm))
+	var blocked []*inFlight
+
+	for _, stepName := range perm {
+		step, sessionName, ok := spec.step(stepName)
+		if !ok {
+			outcomeByStep[stepName] = StepOutcome{Step: stepName, Err: fmt.Errorf("isotest: unknown step %q", stepName)}
+			continue
+		}
+		sc := sessions[sessionName]
+
+		// A session can only have one statement in flight at a time; if an
+		// earlier step on this session is still blocked, wait for it here
+		// before dispatching the next one.
+		r.drainBlockedForSession(sessionName, &blocked, spec, outcomeByStep)
+
+		outcome, stillBlocked := r.dispatchStep(ctx, sc, step)
+		if stillBlocked != nil {
+			blocked = append(blocked, stillBlocked)
+		} else {
+			outcomeByStep[stepName] = finalizeOutcome(outcome, step)
+		}
+
+		// Dispatching (and especially committing/rolling back) a step may
+		// have freed up locks held for other, already-blocked steps.
+		r.collectUnblocked(&blocked, spec, outcomeByStep)
+	}
+
+	// Anything still blocked at the end of the permutation must eventually
+	// finish (or the permutation genuinely deadlocks); wait it out.
+	for _, pending := range blocked {
+		outcome := <-pending.done
+		step, _, _ := spec.step(pending.stepName)
+		outcomeByStep[pending.stepName] = finalizeOutcome(outcome, step)
+	}
+
+	results := make([]StepOutcome, 0, len(perm))
+	for _, stepName := range perm {
+		results = append(results, outcomeByStep[stepName])
+	}
+	return results
+}
+
+// dispatchStep runs step.SQL on sc in a goroutine and polls pg_stat_activity
+// every blockPollInterval until the step either finishes or is reported as
+// waiting on a lock. It returns either a completed outcome, or (if blocked)
+// an inFlight handle the caller can collect later via collectUnblocked.
+func (r *Runner) dispatchStep(ctx context.Context, sc *sessionConn, step Step) (StepOutcome, *inFlight) {
+	done := make(chan StepOutcome, 1)
+	go func() {
+		tag, err := sc.conn.Exec(ctx, step.SQL)
+		observed := ""
+		if err == nil {
+			observed = tag.String()
+		}
+		done <- StepOutcome{Step: step.Name, Session: sc.name, Observed: observed, Err: err}
+	}()
+
+	// A step not reported as blocked yet (e.g. a slow query) is polled
+	// again rather than mis-reported as a lock wait.
+	ticker := time.NewTicker(blockPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case outcome := <-done:
+			return outcome, nil
+		case <-ticker.C:
+			if r.isBlocked(ctx, sc.pid) {
+				return StepOutcome{}, &inFlight{stepName: step.Name, session: sc.name, done: done}
+			}
+		}
+	}
+}
+
+// isBlocked reports whether the backend at pid is currently waiting on a
+// heavyweight or lightweight lock, per pg_stat_activity.
+func (r *Runner) isBlocked(ctx context.Context, pid int32) bool {
+	var waitEventType *string
+	err := r.pool.QueryRow(ctx,
+		"SELECT wait_event_type FROM pg_stat_activity WHERE pid = $1", pid,
+	).Scan(&waitEventType)
+	if err != nil || waitEventType == nil {
+		return false
+	}
+	return *waitEventType == "Lock" || *waitEventType == "LWLock"
+}
+
+// drainBlockedForSession waits for sessionName's blocked step (if any) to
+// finish before the caller dispatches another step on the same connection.
+func (r *Runner) drainBlockedForSession(sessionName string, blocked *[]*inFlight, spec Spec, outcomeByStep map[string]StepOutcome) {
+	remaining := (*blocked)[:0]
+	for _, pending := range *blocked {
+		if pending.session == sessionName {
+			step, _, _ := spec.step(pending.stepName)
+			outcomeByStep[pending.stepName] = finalizeOutcome(<-pending.done, step)
+			continue
+		}
+		remaining = append(remaining, pending)
+	}
+	*blocked = remaining
+}
+
+// collectUnblocked non-blockingly checks every still-blocked step to see if
+// it has since completed, recording its outcome if so.
+func (r *Runner) collectUnblocked(blocked *[]*inFlight, spec Spec, outcomeByStep map[string]StepOutcome) {
+	remaining := (*blocked)[:0]
+	for _, pending := range *blocked {
+		select {
+		case outcome := <-pending.done:
+			step, _, _ := spec.step(pending.stepName)
+			outcomeByStep[pending.stepName] = finalizeOutcome(outcome, step)
+		default:
+			remaining = append(remaining, pending)
+		}
+	}
+	*blocked = remaining
+}
+
+func finalizeOutcome(outcome StepOutcome, step Step) StepOutcome {
+	if step.Expected == "" {
+		return outcome
+	}
+	observed := outcome.Observed
+	if outcome.Err != nil {
+		observed = outcome.Err.Error()
+	}
+	matched := observed == step.Expected
+	outcome.Matched = &matched
+	return outcome
+}
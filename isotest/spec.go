@@ -0,0 +1,58 @@
+// Package isotest is a small, Go-native take on Postgres's
+// pg_isolation_regress: specs declare a handful of named sessions, the SQL
+// steps each session can run, and one or more orderings ("permutations") in
+// which those steps should be dispatched. The Runner executes a permutation
+// by handing each step to its session's connection and threading around
+// steps that block on a lock, the same way pg_isolation_regress does.
+package isotest
+
+// Step is a single SQL statement belonging to a Session. Name must be
+// unique within a Spec so it can be referenced from a Permutation.
+type Step struct {
+	Name string
+	SQL  string
+
+	// Expected is the observed-result string a passing run must produce for
+	// this step (see StepOutcome.Observed). Left empty, the runner records
+	// the outcome but skips the comparison.
+	Expected string
+}
+
+// Session is one concurrent transaction: a named connection plus the steps
+// that may run on it, in the order they appear here.
+type Session struct {
+	Name  string
+	Steps []Step
+}
+
+// Permutation is an ordering of step names (drawn from any Session) in
+// which the runner should dispatch them.
+type Permutation []string
+
+// Spec is a declarative permutation test: a setup block shared by every
+// permutation, the sessions and steps available, and the permutations to
+// run against them.
+type Spec struct {
+	Name string
+
+	// Setup runs once, on its own connection, before each permutation.
+	Setup string
+
+	// Teardown runs once, on its own connection, after each permutation,
+	// win or lose. It is optional.
+	Teardown string
+
+	Sessions     []Session
+	Permutations []Permutation
+}
+
+func (s Spec) step(name string) (Step, string, bool) {
+	for _, sess := range s.Sessions {
+		for _, step := range sess.Steps {
+			if step.Name == name {
+				return step, sess.Name, true
+			}
+		}
+	}
+	return Step{}, "", false
+}
@@ -0,0 +1,106 @@
+package transaction_isolation
+
+import (
+	"context"
+	"errors"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// ErrStaleVersion is returned by SetBalanceOCC when the row's version
+// column no longer matches the version the caller read, meaning another
+// writer committed in between.
+var ErrStaleVersion = errors.New("transaction_isolation: stale version")
+
+// BalanceOCC is a Balance plus the version column optimistic concurrency
+// control checks against.
+type BalanceOCC struct {
+	Name    string `db:"name"`
+	Value   int    `db:"value"`
+	Version int    `db:"version"`
+}
+
+// GetBalanceOCC reads a balance along with the version it must be passed
+// back into SetBalanceOCC to avoid an ErrStaleVersion.
+func GetBalanceOCC(ctx context.Context, connOrTx ConnOrTx, name string) *BalanceOCC {
+	var balance BalanceOCC
+	err := pgxscan.Get(ctx, connOrTx, &balance, "SELECT value, name, version FROM dev.balances WHERE name = $1", name)
+	if err != nil {
+		panic(err)
+	}
+
+	return &balance
+}
+
+// SetBalanceOCC writes value only if the row's version still equals
+// expectedVersion (the version GetBalanceOCC returned), bumping version by
+// one in the process. It returns ErrStaleVersion if a concurrent writer
+// already moved the version on, regardless of isolation level.
+//
+// At RepeatableRead and Serializable the mismatch is never actually
+// evaluated: Postgres's first-updater-wins rule raises 40001 on the
+// UPDATE itself once a concurrent commit has touched the row, the same
+// way it does for the pre-existing LostUpdate. SetBalanceOCC treats that
+// 40001 as an ErrStaleVersion too, so callers get one consistent error
+// regardless of which isolation level caught the conflict.
+func SetBalanceOCC(ctx context.Context, connOrTx ConnOrTx, name string, value int, expectedVersion int) error {
+	tag, err := connOrTx.Exec(ctx,
+		"UPDATE dev.balances SET value = $1, version = version + 1 WHERE name = $2 AND version = $3",
+		value, name, expectedVersion)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "40001" {
+			return ErrStaleVersion
+		}
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrStaleVersion
+	}
+
+	return nil
+}
+
+/*
+	Same setup as LostUpdate, but writing through SetBalanceOCC instead of
+	SetBalance. Unlike LostUpdate, this rejects the second writer at every
+	isolation level - including ReadCommitted, where LostUpdate itself
+	succeeds silently.
+*/
+func LostUpdateOCC(ctx context.Context, tx1, tx2 pgx.Tx) (bool, error) {
+	defer tx1.Rollback(ctx)
+	defer tx2.Rollback(ctx)
+
+	// Tx1 reads Alice balance
+	aliceBal := GetBalanceOCC(ctx, tx1, Alice)
+	// Tx2 reads Alice balance
+	aliceBal2 := GetBalanceOCC(ctx, tx2, Alice)
+
+	// Tx1 writes to Alice Balance
+	err := SetBalanceOCC(ctx, tx1, Alice, aliceBal.Value+50, aliceBal.Version)
+	if err != nil {
+		panic(err)
+	}
+	err = tx1.Commit(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	// Tx2 tries to write to Alice Balance using its now-stale version
+	err = SetBalanceOCC(ctx, tx2, Alice, aliceBal2.Value+100, aliceBal2.Version)
+	if err != nil {
+		// Expected at every isolation level: tx1's commit already bumped
+		// the version tx2 read.
+		return false, err
+	}
+	err = tx2.Commit(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	// if SetBalanceOCC does not error a lost update anomaly has occured
+	return true, nil
+}
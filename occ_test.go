@@ -0,0 +1,30 @@
+package transaction_isolation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+	OCC behaves identically at every isolation level: LostUpdateOCC is
+	rejected with ErrStaleVersion regardless of the level the transactions
+	were opened at, unlike LostUpdate which only RepeatableRead and above
+	catch.
+*/
+func TestLostUpdateOCC(t *testing.T) {
+	for _, isoLevel := range []pgx.TxIsoLevel{pgx.ReadCommitted, pgx.RepeatableRead, pgx.Serializable} {
+		isoLevel := isoLevel
+		t.Run(string(isoLevel), func(t *testing.T) {
+			resetTable()
+			tx1, tx2 := beginTransactions(isoLevel)
+
+			anomaly, err := LostUpdateOCC(ctx, tx1, tx2)
+
+			assert.False(t, anomaly)
+			assert.True(t, errors.Is(err, ErrStaleVersion))
+		})
+	}
+}
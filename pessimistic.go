@@ -0,0 +1,240 @@
+package transaction_isolation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// ErrLockNotAvailable is returned by the NoWait lock helpers when the row
+// is already locked by another transaction, i.e. Postgres's 55P03.
+var ErrLockNotAvailable = errors.New("transaction_isolation: lock not available")
+
+// lockClause is a SELECT row-locking clause (FOR UPDATE, FOR SHARE, ...).
+// NOWAIT or SKIP LOCKED is appended separately, according to lockWait.
+type lockClause string
+
+const (
+	forUpdate      lockClause = "FOR UPDATE"
+	forShare       lockClause = "FOR SHARE"
+	forNoKeyUpdate lockClause = "FOR NO KEY UPDATE"
+)
+
+// lockWait controls what a row-locking SELECT does when the row is already
+// locked by another transaction: block until it's released (the default),
+// fail immediately, or silently skip the row.
+type lockWait int
+
+const (
+	lockWaitBlock lockWait = iota
+	lockWaitNoWait
+	lockWaitSkipLocked
+)
+
+func getBalanceLocked(ctx context.Context, connOrTx ConnOrTx, name string, clause lockClause, wait lockWait) (*Balance, error) {
+	sql := fmt.Sprintf("SELECT value, name FROM dev.balances WHERE name = $1 %s", clause)
+	switch wait {
+	case lockWaitNoWait:
+		sql += " NOWAIT"
+	case lockWaitSkipLocked:
+		sql += " SKIP LOCKED"
+	}
+
+	var balance Balance
+	err := pgxscan.Get(ctx, connOrTx, &balance, sql, name)
+	if err != nil {
+		// Unlike NOWAIT, SKIP LOCKED never errors on a locked row - the row
+		// is just absent from the result set, which scany reports as
+		// pgx.ErrNoRows. Report that as "nothing to return" rather than
+		// wrapping it as a lock failure.
+		if wait == lockWaitSkipLocked && errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, wrapIfLockNotAvailable(err)
+	}
+
+	return &balance, nil
+}
+
+func wrapIfLockNotAvailable(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "55P03" {
+		return fmt.Errorf("%w: %v", ErrLockNotAvailable, err)
+	}
+	return err
+}
+
+// GetBalanceForUpdate reads name's balance, taking a FOR UPDATE lock that's
+// held until the transaction ends. A concurrent FOR UPDATE/FOR SHARE on the
+// same row blocks until that happens.
+func GetBalanceForUpdate(ctx context.Context, connOrTx ConnOrTx, name string) (*Balance, error) {
+	return getBalanceLocked(ctx, connOrTx, name, forUpdate, lockWaitBlock)
+}
+
+// GetBalanceForUpdateNoWait is GetBalanceForUpdate, but returns
+// ErrLockNotAvailable immediately instead of blocking if the row is
+// already locked.
+func GetBalanceForUpdateNoWait(ctx context.Context, connOrTx ConnOrTx, name string) (*Balance, error) {
+	return getBalanceLocked(ctx, connOrTx, name, forUpdate, lockWaitNoWait)
+}
+
+// GetBalanceForUpdateSkipLocked is GetBalanceForUpdate, but silently omits
+// the row instead of blocking or erroring if it's already locked. Unlike
+// GetBalanceForUpdateNoWait, a locked row isn't reported as
+// ErrLockNotAvailable - it comes back as a nil Balance and a nil error, the
+// same as SKIP LOCKED itself just not returning the row.
+func GetBalanceForUpdateSkipLocked(ctx context.Context, connOrTx ConnOrTx, name string) (*Balance, error) {
+	return getBalanceLocked(ctx, connOrTx, name, forUpdate, lockWaitSkipLocked)
+}
+
+// GetBalanceForShare reads name's balance, taking a FOR SHARE lock: it
+// blocks concurrent FOR UPDATE on the row, but not other FOR SHARE readers.
+func GetBalanceForShare(ctx context.Context, connOrTx ConnOrTx, name string) (*Balance, error) {
+	return getBalanceLocked(ctx, connOrTx, name, forShare, lockWaitBlock)
+}
+
+// GetBalanceForNoKeyUpdate is GetBalanceForUpdate's weaker sibling: it
+// locks against other FOR UPDATE/FOR NO KEY UPDATE writers, but still
+// allows a concurrent FOR KEY SHARE (e.g. a foreign key check) through.
+func GetBalanceForNoKeyUpdate(ctx context.Context, connOrTx ConnOrTx, name string) (*Balance, error) {
+	return getBalanceLocked(ctx, connOrTx, name, forNoKeyUpdate, lockWaitBlock)
+}
+
+// SetBalanceForUpdate locks name's row with GetBalanceForUpdate before
+// writing value, so a caller doing read-then-write can be sure no other
+// transaction slipped in a write between the two - unlike GetBalance plus
+// SetBalance, where the row is unlocked for the whole gap in between.
+func SetBalanceForUpdate(ctx context.Context, connOrTx ConnOrTx, name string, value int) (*Balance, error) {
+	balance, err := GetBalanceForUpdate(ctx, connOrTx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := SetBalance(ctx, connOrTx, name, value); err != nil {
+		return nil, err
+	}
+
+	return balance, nil
+}
+
+/*
+	Same setup as LostUpdate, but Tx2 re-reads Alice's row with FOR UPDATE
+	before writing it and computes its new value from that locked read.
+	Tx2 requests that lock only after Tx1 has committed and released its
+	own, so at ReadCommitted Tx2's locked read can never miss Tx1's write,
+	without needing RepeatableRead's 40001 to catch it.
+
+	At RepeatableRead and Serializable, though, the same first-updater-wins
+	rule that gives plain LostUpdate a 40001 also applies to SELECT ... FOR
+	UPDATE: Tx2's snapshot predates Tx1's commit, so Tx2's lock request
+	fails with a 40001 rather than returning the row, so this still returns
+	an error there - just via the lock instead of the later write.
+*/
+func LostUpdatePessimistic(ctx context.Context, tx1, tx2 pgx.Tx) (bool, error) {
+	defer tx1.Rollback(ctx)
+	defer tx2.Rollback(ctx)
+
+	// Tx1 locks and reads Alice's balance
+	aliceBal, err := GetBalanceForUpdate(ctx, tx1, Alice)
+	if err != nil {
+		return false, err
+	}
+
+	// Tx2 reads Alice's balance without a lock, as in LostUpdate; this also
+	// fixes Tx2's snapshot at RepeatableRead and above
+	_ = GetBalance(ctx, tx2, Alice)
+
+	// Tx1 writes to Alice's balance and commits, releasing its lock
+	err = SetBalance(ctx, tx1, Alice, aliceBal.Value+50)
+	if err != nil {
+		panic(err)
+	}
+	err = tx1.Commit(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	// Tx2's lock request is made only after Tx1's commit above released
+	// Tx1's lock, so this read reflects Tx1's write rather than racing past it
+	aliceBal2, err := GetBalanceForUpdate(ctx, tx2, Alice)
+	if err != nil {
+		return false, err
+	}
+
+	err = SetBalance(ctx, tx2, Alice, aliceBal2.Value+100)
+	if err != nil {
+		return false, err
+	}
+	err = tx2.Commit(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	// a lost update anomaly would mean Tx2 computed its new value from the
+	// balance Tx1 already overwrote
+	return aliceBal2.Value == aliceBal.Value, nil
+}
+
+/*
+	Same setup as WriteSkew, but before writing, each transaction locks both
+	Alice's and Bob's rows with FOR UPDATE and reads through those locks
+	what its write depends on. Locking both rows
+	(not just the one each transaction intends to write) turns the two
+	transactions' overlapping reads into a real lock conflict, so
+	RepeatableRead's serialization check has something to catch.
+*/
+func WriteSkewPessimistic(ctx context.Context, tx1, tx2 pgx.Tx) (bool, error) {
+	defer tx1.Rollback(ctx)
+	defer tx2.Rollback(ctx)
+
+	// Tx1 locks and reads both balances
+	aliceBal, err := GetBalanceForUpdate(ctx, tx1, Alice)
+	if err != nil {
+		return false, err
+	}
+	if _, err := GetBalanceForUpdate(ctx, tx1, Bob); err != nil {
+		return false, err
+	}
+
+	// Tx2 reads Bob's balance without a lock, as in WriteSkew; this also
+	// fixes Tx2's snapshot at RepeatableRead and above
+	_ = GetBalance(ctx, tx2, Bob)
+
+	// Tx1 updates Bob's balance and commits, releasing both locks
+	err = SetBalance(ctx, tx1, Bob, aliceBal.Value+50)
+	if err != nil {
+		panic(err)
+	}
+	err = tx1.Commit(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	// Tx2's lock requests are made only after Tx1's commit above released
+	// Tx1's locks; at RepeatableRead, Bob having been changed since Tx2's
+	// snapshot began surfaces as a 40001 here rather than letting Tx2
+	// proceed on stale data
+	if _, err := GetBalanceForUpdate(ctx, tx2, Alice); err != nil {
+		return false, err
+	}
+	bobBal, err := GetBalanceForUpdate(ctx, tx2, Bob)
+	if err != nil {
+		return false, err
+	}
+
+	err = SetBalance(ctx, tx2, Alice, bobBal.Value+50)
+	if err != nil {
+		return false, err
+	}
+	err = tx2.Commit(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	// if Tx2 saw Tx1's write to Bob, no write skew anomaly has occurred
+	return bobBal.Value == aliceBal.Value, nil
+}
@@ -0,0 +1,137 @@
+package transaction_isolation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+	FOR UPDATE prevents LostUpdate without needing RepeatableRead's 40001 -
+	but only at ReadCommitted. At RepeatableRead and Serializable, the same
+	first-updater-wins rule that already gives plain LostUpdate a 40001
+	applies to SELECT ... FOR UPDATE too: Tx2's lock request, made after Tx1
+	commits, fails with a 40001 rather than returning the row.
+*/
+func TestLostUpdatePessimistic(t *testing.T) {
+	t.Run("ReadCommitted: Tx2 observes Tx1's write", func(t *testing.T) {
+		resetTable()
+		tx1, tx2 := beginTransactions(pgx.ReadCommitted)
+
+		anomaly, err := LostUpdatePessimistic(ctx, tx1, tx2)
+
+		assert.NoError(t, err)
+		assert.False(t, anomaly)
+	})
+
+	for _, isoLevel := range []pgx.TxIsoLevel{pgx.RepeatableRead, pgx.Serializable} {
+		isoLevel := isoLevel
+		t.Run(string(isoLevel)+": Tx2's lock request surfaces 40001", func(t *testing.T) {
+			resetTable()
+			tx1, tx2 := beginTransactions(isoLevel)
+
+			_, err := LostUpdatePessimistic(ctx, tx1, tx2)
+			assert.Error(t, err)
+
+			var pgErr *pgconn.PgError
+			errors.As(err, &pgErr)
+			assert.Equal(t, "40001", pgErr.Code)
+		})
+	}
+}
+
+/*
+	FOR UPDATE on both balances prevents WriteSkew, either by forcing Tx2 to
+	observe Tx1's write (ReadCommitted) or by surfacing the conflict as a
+	40001 on Tx2's lock request (RepeatableRead and above).
+*/
+func TestWriteSkewPessimistic(t *testing.T) {
+	t.Run("ReadCommitted: Tx2 observes Tx1's write", func(t *testing.T) {
+		resetTable()
+		tx1, tx2 := beginTransactions(pgx.ReadCommitted)
+
+		anomaly, err := WriteSkewPessimistic(ctx, tx1, tx2)
+
+		assert.NoError(t, err)
+		assert.False(t, anomaly)
+	})
+
+	t.Run("RepeatableRead: Tx2's lock request surfaces 40001", func(t *testing.T) {
+		resetTable()
+		tx1, tx2 := beginTransactions(pgx.RepeatableRead)
+
+		_, err := WriteSkewPessimistic(ctx, tx1, tx2)
+		assert.Error(t, err)
+
+		var pgErr *pgconn.PgError
+		errors.As(err, &pgErr)
+		assert.Equal(t, "40001", pgErr.Code)
+	})
+}
+
+func TestGetBalanceForUpdateNoWait(t *testing.T) {
+	resetTable()
+	tx1, tx2 := beginTransactions(pgx.ReadCommitted)
+	defer tx1.Rollback(ctx)
+	defer tx2.Rollback(ctx)
+
+	// Tx1 takes (and holds) the lock on Alice's row
+	_, err := GetBalanceForUpdate(ctx, tx1, Alice)
+	assert.NoError(t, err)
+
+	// Tx2's NOWAIT request can't wait it out, so it fails immediately
+	_, err = GetBalanceForUpdateNoWait(ctx, tx2, Alice)
+	assert.True(t, errors.Is(err, ErrLockNotAvailable))
+}
+
+func TestGetBalanceForUpdateSkipLocked(t *testing.T) {
+	resetTable()
+	tx1, tx2 := beginTransactions(pgx.ReadCommitted)
+	defer tx1.Rollback(ctx)
+	defer tx2.Rollback(ctx)
+
+	// Tx1 takes (and holds) the lock on Alice's row
+	_, err := GetBalanceForUpdate(ctx, tx1, Alice)
+	assert.NoError(t, err)
+
+	// Tx2's SKIP LOCKED request doesn't wait or error the way NoWait does -
+	// the locked row is just absent from the result
+	balance, err := GetBalanceForUpdateSkipLocked(ctx, tx2, Alice)
+	assert.NoError(t, err)
+	assert.Nil(t, balance)
+}
+
+func TestGetBalanceForShare(t *testing.T) {
+	resetTable()
+	tx1, tx2 := beginTransactions(pgx.ReadCommitted)
+	defer tx1.Rollback(ctx)
+	defer tx2.Rollback(ctx)
+
+	// Tx1 takes a FOR SHARE lock on Alice's row
+	_, err := GetBalanceForShare(ctx, tx1, Alice)
+	assert.NoError(t, err)
+
+	// Tx2's own FOR SHARE doesn't conflict with Tx1's, so a NOWAIT request
+	// for it isn't blocked
+	_, err = getBalanceLocked(ctx, tx2, Alice, forShare, lockWaitNoWait)
+	assert.NoError(t, err)
+}
+
+func TestGetBalanceForNoKeyUpdate(t *testing.T) {
+	resetTable()
+	tx1, tx2 := beginTransactions(pgx.ReadCommitted)
+	defer tx1.Rollback(ctx)
+	defer tx2.Rollback(ctx)
+
+	// Tx1 takes a FOR NO KEY UPDATE lock on Alice's row
+	_, err := GetBalanceForNoKeyUpdate(ctx, tx1, Alice)
+	assert.NoError(t, err)
+
+	// Tx2's FOR UPDATE still conflicts with Tx1's FOR NO KEY UPDATE, unlike
+	// FOR KEY SHARE would
+	_, err = getBalanceLocked(ctx, tx2, Alice, forUpdate, lockWaitNoWait)
+	assert.True(t, errors.Is(err, ErrLockNotAvailable))
+}
@@ -0,0 +1,108 @@
+package transaction_isolation
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+/*
+	ReadOnlyAnomaly is the classic SSI read-only anomaly (Cahill et al.,
+	"Serializable Isolation for Snapshot Databases"): Tx1 and Tx2 don't
+	conflict with each other directly - the cycle that makes this
+	unserializable only closes through the read-only Tx3's dependencies.
+
+	Tx1 debits Alice by a fixed amount, entirely independent of Bob. Tx2
+	separately reads Bob and credits it, entirely independent of Tx1's
+	write. The only edge between Tx1 and Tx2 themselves is "Tx2 read
+	Alice before Tx1 wrote it", which alone isn't a cycle (compare
+	WriteSkew, where Tx1 and Tx2 each read what the other writes - a
+	direct 2-cycle between just the two of them). It's Tx3 reading Alice
+	after Tx1's commit but Bob before Tx2's that closes the loop: Tx2 must
+	serialize before Tx1 (it read Alice's pre-Tx1 value), Tx1 before Tx3
+	(Tx3 saw Tx1's write), and Tx3 before Tx2 (Tx3 read Bob's pre-Tx2
+	value) - Tx2 < Tx1 < Tx3 < Tx2, a contradiction no serial order can
+	satisfy. Under plain RepeatableRead (snapshot isolation) all three
+	transactions commit anyway; Serializable detects the cycle and aborts
+	one of them.
+*/
+func ReadOnlyAnomaly(ctx context.Context, tx1, tx2, tx3 pgx.Tx) error {
+	defer tx1.Rollback(ctx)
+	defer tx2.Rollback(ctx)
+	defer tx3.Rollback(ctx)
+
+	// Tx2 reads Alice's starting balance, before Tx1 has written it
+	aliceBal := GetBalance(ctx, tx2, Alice)
+
+	// Tx1 debits Alice by a fixed amount - it never reads Bob, so it has
+	// no direct dependency on Tx2's write to Bob - and commits
+	err := SetBalance(ctx, tx1, Alice, aliceBal.Value-50)
+	if err != nil {
+		return err
+	}
+	err = tx1.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Tx3, read-only, starts after Tx1 has committed but before Tx2 has -
+	// its single snapshot can only ever show Tx1's effect on Alice, never
+	// Tx2's not-yet-committed effect on Bob. Those values aren't compared
+	// against anything here: given this fixed call order, Tx3's Alice read
+	// always lands after Tx1's commit and its Bob read always lands before
+	// Tx2's write, so any such comparison would just confirm the ordering
+	// this function itself imposes, not observe the anomaly. The only real
+	// signal that Serializable caught the cycle is the 40001 on one of the
+	// three commits below, which callers check for.
+	_ = GetBalance(ctx, tx3, Alice)
+	_ = GetBalance(ctx, tx3, Bob)
+	err = tx3.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Tx2 does its own, independent read of Bob - this is what keeps Tx1
+	// and Tx2 from conflicting with each other directly - and credits it
+	bobBal := GetBalance(ctx, tx2, Bob)
+	err = SetBalance(ctx, tx2, Bob, bobBal.Value+50)
+	if err != nil {
+		return err
+	}
+	return tx2.Commit(ctx)
+}
+
+/*
+	SnapshotTooOld demonstrates the cost side of MVCC: tx is a long-held
+	RepeatableRead transaction, and while it's open a concurrent writer
+	commits a change to dev.balances and a VACUUM reclaims the row version
+	tx's snapshot still needs. With old_snapshot_threshold set low enough
+	(a server-level GUC - this is not something a session can turn on for
+	itself), Postgres would rather error than let tx's snapshot grow
+	unboundedly expensive to maintain, and the second read of Alice here
+	fails with 72000 ("snapshot too old") instead of returning the
+	pre-VACUUM row; SnapshotTooOld returns that error.
+*/
+func SnapshotTooOld(ctx context.Context, pool ConnOrTx, tx pgx.Tx, writer pgx.Tx) error {
+	// tx takes its snapshot before the concurrent write below
+	_ = GetBalance(ctx, tx, Alice)
+
+	if err := SetBalance(ctx, writer, Alice, 999); err != nil {
+		return err
+	}
+	if err := writer.Commit(ctx); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, "VACUUM dev.balances"); err != nil {
+		return err
+	}
+
+	// With old_snapshot_threshold configured low enough, this read of a row
+	// version VACUUM has since reclaimed returns a 72000 error rather than
+	// the value tx's snapshot was entitled to.
+	var aliceValue int
+	if err := tx.QueryRow(ctx, "SELECT value FROM dev.balances WHERE name = $1", Alice).Scan(&aliceValue); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
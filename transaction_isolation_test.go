@@ -34,7 +34,8 @@ CREATE SCHEMA IF NOT EXISTS dev;
 DROP TABLE IF EXISTS dev.balances;
 CREATE TABLE dev.balances (
 	name TEXT NOT NULL PRIMARY KEY,
-	value int NOT NULL
+	value int NOT NULL,
+	version INT NOT NULL DEFAULT 0
 );
 	`)
 
@@ -0,0 +1,142 @@
+package transaction_isolation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// txContextKey is an unexported type so values stored by this package can't
+// collide with context keys set elsewhere.
+type txContextKey struct{}
+
+// TxManager wraps a pgxpool.Pool and runs callbacks inside a transaction at a
+// chosen isolation level, so callers don't have to hand-roll BeginTx/Commit/
+// Rollback (or, for Serializable, a retry loop) around every demo function.
+type TxManager struct {
+	pool *pgxpool.Pool
+}
+
+// NewTxManager returns a TxManager backed by pool.
+func NewTxManager(pool *pgxpool.Pool) *TxManager {
+	return &TxManager{pool: pool}
+}
+
+// TxFromContext returns the pgx.Tx that a TxManager call placed on ctx, if
+// any. It lets an fn passed to TxManager recover that transaction and pass
+// it as the ConnOrTx argument of helpers like SetBalance and GetBalance.
+func TxFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(pgx.Tx)
+	return tx, ok
+}
+
+// ReadCommitted runs fn inside a ReadCommitted transaction, committing on a
+// nil return and rolling back otherwise.
+func (m *TxManager) ReadCommitted(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.runOnce(ctx, pgx.ReadCommitted, fn)
+}
+
+// RepeatableRead runs fn inside a RepeatableRead transaction, committing on
+// a nil return and rolling back otherwise.
+func (m *TxManager) RepeatableRead(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.runOnce(ctx, pgx.RepeatableRead, fn)
+}
+
+// Serializable runs fn inside a Serializable transaction, automatically
+// retrying with exponential backoff and jitter when Postgres reports a
+// serialization failure (40001) or deadlock (40P01), up to numAttempts
+// total tries. Any other error is returned immediately without retrying.
+func (m *TxManager) Serializable(ctx context.Context, numAttempts int, fn func(ctx context.Context) error) error {
+	if numAttempts < 1 {
+		return fmt.Errorf("transaction_isolation: numAttempts must be at least 1, got %d", numAttempts)
+	}
+
+	if _, ok := TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	var err error
+	for attempt := 0; attempt < numAttempts; attempt++ {
+		if attempt > 0 {
+			if waitErr := sleepBackoff(ctx, attempt); waitErr != nil {
+				return waitErr
+			}
+		}
+
+		err = m.runOnce(ctx, pgx.Serializable, fn)
+		if err == nil {
+			return nil
+		}
+		if !isRetryableSerializationError(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// runOnce begins a transaction at isoLevel and invokes fn with the
+// transaction attached to ctx, unless ctx already carries a transaction
+// (e.g. an outer TxManager call, or a nested Serializable retry), in which
+// case fn joins it instead of opening a new one.
+func (m *TxManager) runOnce(ctx context.Context, isoLevel pgx.TxIsoLevel, fn func(ctx context.Context) error) error {
+	if _, ok := TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: isoLevel})
+	if err != nil {
+		return err
+	}
+
+	txCtx := context.WithValue(ctx, txContextKey{}, tx)
+	if err := fn(txCtx); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// isRetryableSerializationError reports whether err is a Postgres
+// serialization failure (40001) or deadlock (40P01), the two conditions
+// Serializable retries.
+func isRetryableSerializationError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == "40001" || pgErr.Code == "40P01"
+}
+
+const (
+	serializableBackoffBase = 10 * time.Millisecond
+	serializableBackoffMax  = 1 * time.Second
+)
+
+// sleepBackoff waits out an exponential backoff (with full jitter) before
+// the given retry attempt, returning early if ctx is cancelled.
+func sleepBackoff(ctx context.Context, attempt int) error {
+	backoff := time.Duration(float64(serializableBackoffBase) * math.Pow(2, float64(attempt-1)))
+	if backoff > serializableBackoffMax {
+		backoff = serializableBackoffMax
+	}
+	jittered := time.Duration(rand.Int63n(int64(backoff) + 1))
+
+	timer := time.NewTimer(jittered)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
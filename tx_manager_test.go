@@ -0,0 +1,93 @@
+package transaction_isolation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+	TxManager exercises the same demo functions as the isolation-level tests
+	in transaction_isolation_test.go, but through the high level retrying API instead of hand-managed
+	pgx.Tx values.
+*/
+func TestTxManager(t *testing.T) {
+	t.Run("ReadCommitted commits on success", func(t *testing.T) {
+		resetTable()
+		mgr := NewTxManager(pool)
+
+		err := mgr.ReadCommitted(ctx, func(ctx context.Context) error {
+			tx, _ := TxFromContext(ctx)
+			return SetBalance(ctx, tx, Alice, 200)
+		})
+		assert.NoError(t, err)
+
+		tx, err := pool.Begin(ctx)
+		assert.NoError(t, err)
+		defer tx.Rollback(ctx)
+		assert.Equal(t, 200, GetBalance(ctx, tx, Alice).Value)
+	})
+
+	t.Run("RepeatableRead rolls back on error", func(t *testing.T) {
+		resetTable()
+		mgr := NewTxManager(pool)
+
+		err := mgr.RepeatableRead(ctx, func(ctx context.Context) error {
+			tx, _ := TxFromContext(ctx)
+			if err := SetBalance(ctx, tx, Alice, 300); err != nil {
+				return err
+			}
+			return assert.AnError
+		})
+		assert.Error(t, err)
+
+		tx, err := pool.Begin(ctx)
+		assert.NoError(t, err)
+		defer tx.Rollback(ctx)
+		assert.Equal(t, 100, GetBalance(ctx, tx, Alice).Value)
+	})
+
+	t.Run("Serializable rejects a non-positive numAttempts instead of silently skipping fn", func(t *testing.T) {
+		mgr := NewTxManager(pool)
+
+		called := false
+		err := mgr.Serializable(ctx, 0, func(ctx context.Context) error {
+			called = true
+			return nil
+		})
+
+		assert.Error(t, err)
+		assert.False(t, called)
+	})
+
+	t.Run("Serializable calls fn once when the first attempt succeeds", func(t *testing.T) {
+		resetTable()
+		mgr := NewTxManager(pool)
+
+		calls := 0
+		err := mgr.Serializable(ctx, 3, func(ctx context.Context) error {
+			calls++
+			tx, _ := TxFromContext(ctx)
+			return SetBalance(ctx, tx, Alice, 400)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Serializable joins an already open transaction", func(t *testing.T) {
+		resetTable()
+		mgr := NewTxManager(pool)
+
+		err := mgr.ReadCommitted(ctx, func(ctx context.Context) error {
+			outerTx, _ := TxFromContext(ctx)
+
+			return mgr.Serializable(ctx, 3, func(innerCtx context.Context) error {
+				innerTx, _ := TxFromContext(innerCtx)
+				assert.Same(t, outerTx, innerTx)
+				return SetBalance(innerCtx, innerTx, Alice, 500)
+			})
+		})
+		assert.NoError(t, err)
+	})
+}